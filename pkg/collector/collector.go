@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"context"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Collector receives the log streams and events that the watcher collects
+// for matched pods and is responsible for getting them to wherever the user
+// wants them (disk, stdout, …).
+type Collector interface {
+	// CollectLogs is called once per container incarnation and should block
+	// until stream is exhausted or ctx is cancelled. restartCount identifies
+	// which incarnation this is, so implementations that persist to disk can
+	// start each restart in a fresh base file instead of appending to (or
+	// clobbering) the previous incarnation's.
+	CollectLogs(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, containerName string, restartCount int, stream io.ReadCloser) error
+
+	// CollectEvent is called once for every corev1.Event involving pod that
+	// the watcher observes, for as long as ctx is not cancelled.
+	CollectEvent(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, event *corev1.Event) error
+}
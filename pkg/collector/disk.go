@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DiskCollector writes logs and events into a directory tree, namespaced by
+// pod and container/event kind, so that one loks invocation can collect
+// from many pods at once without clobbering anything.
+type DiskCollector struct {
+	baseDir       string
+	rotation      RotationOptions
+	dumpEventsRaw bool
+}
+
+// NewDiskCollector returns a Collector that writes everything below baseDir,
+// which is created if it does not yet exist. rotation controls size-capping,
+// pruning and gzip-compression of the per-container log files; its zero
+// value disables all three, matching the previous unbounded behaviour.
+// dumpEventsRaw mirrors watcher.Options.DumpEventsRaw: when false, events
+// are still rendered to events.log, but the raw events.yaml is skipped.
+func NewDiskCollector(baseDir string, rotation RotationOptions, dumpEventsRaw bool) *DiskCollector {
+	return &DiskCollector{
+		baseDir:       baseDir,
+		rotation:      rotation,
+		dumpEventsRaw: dumpEventsRaw,
+	}
+}
+
+func (c *DiskCollector) CollectLogs(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, containerName string, restartCount int, stream io.ReadCloser) error {
+	filename := filepath.Join(c.podDir(pod), logFilename(containerName, restartCount))
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	w, err := newRotatingWriter(filename, c.rotation)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, stream)
+
+	return err
+}
+
+// logFilename returns the base log filename for a container incarnation:
+// restart 0 keeps the plain "<name>.log" name used before rotation support
+// existed, while later restarts get their own file so a crash loop doesn't
+// keep clobbering (or, with rotation, endlessly rotating) the same file.
+func logFilename(containerName string, restartCount int) string {
+	if restartCount == 0 {
+		return fmt.Sprintf("%s.log", containerName)
+	}
+
+	return fmt.Sprintf("%s.restart%d.log", containerName, restartCount)
+}
+
+func (c *DiskCollector) CollectEvent(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, event *corev1.Event) error {
+	if err := c.appendRenderedEvent(pod, event); err != nil {
+		return fmt.Errorf("failed to render event: %w", err)
+	}
+
+	if !c.dumpEventsRaw {
+		return nil
+	}
+
+	if err := c.appendRawEvent(pod, event); err != nil {
+		return fmt.Errorf("failed to write raw event: %w", err)
+	}
+
+	return nil
+}
+
+func (c *DiskCollector) appendRenderedEvent(pod *corev1.Pod, event *corev1.Event) error {
+	f, err := c.openAppend(filepath.Join(c.podDir(pod), "events.log"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "[%s] %s/%s %s: %s\n",
+		event.LastTimestamp.Format("2006-01-02T15:04:05Z07:00"),
+		event.Type,
+		event.Reason,
+		event.InvolvedObject.FieldPath,
+		event.Message,
+	)
+
+	return err
+}
+
+func (c *DiskCollector) appendRawEvent(pod *corev1.Pod, event *corev1.Event) error {
+	f, err := c.openAppend(filepath.Join(c.podDir(pod), "events.yaml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoded, err := yaml.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(f, "---\n%s", encoded)
+
+	return err
+}
+
+func (c *DiskCollector) podDir(pod *corev1.Pod) string {
+	return filepath.Join(c.baseDir, pod.Namespace, pod.Name)
+}
+
+func (c *DiskCollector) openAppend(filename string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
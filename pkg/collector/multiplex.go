@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"context"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// MultiplexCollector fans CollectLogs/CollectEvent calls out to a set of
+// underlying collectors, so a single watch can e.g. write both to disk and
+// to stdout.
+type MultiplexCollector struct {
+	collectors []Collector
+}
+
+// NewMultiplexCollector returns a Collector that forwards every call to all
+// of the given collectors.
+func NewMultiplexCollector(collectors ...Collector) *MultiplexCollector {
+	return &MultiplexCollector{
+		collectors: collectors,
+	}
+}
+
+func (c *MultiplexCollector) CollectLogs(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, containerName string, restartCount int, stream io.ReadCloser) error {
+	readers := make([]*io.PipeReader, len(c.collectors))
+	writers := make([]*io.PipeWriter, len(c.collectors))
+	mw := make([]io.Writer, len(c.collectors))
+
+	for i := range c.collectors {
+		pr, pw := io.Pipe()
+		readers[i] = pr
+		writers[i] = pw
+		mw[i] = pw
+	}
+
+	go func() {
+		io.Copy(io.MultiWriter(mw...), stream)
+		for _, w := range writers {
+			w.Close()
+		}
+	}()
+
+	errs := make(chan error, len(c.collectors))
+	for i, collector := range c.collectors {
+		go func(collector Collector, r *io.PipeReader) {
+			err := collector.CollectLogs(ctx, log, pod, containerName, restartCount, r)
+
+			// The collector may have returned before stream was exhausted
+			// (e.g. it failed to open its destination). Since the shared
+			// io.Copy above writes to every pipe in lock-step via
+			// io.MultiWriter, an un-drained reader here would permanently
+			// stall delivery to all the *other* collectors too. Keep
+			// draining (and discarding) until the writer side closes us.
+			io.Copy(io.Discard, r)
+
+			errs <- err
+		}(collector, readers[i])
+	}
+
+	var firstErr error
+	for range c.collectors {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (c *MultiplexCollector) CollectEvent(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, event *corev1.Event) error {
+	var firstErr error
+	for _, collector := range c.collectors {
+		if err := collector.CollectEvent(ctx, log, pod, event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
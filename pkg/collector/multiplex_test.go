@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// failFastCollector returns an error immediately without reading its stream,
+// simulating a collector that fails to open its destination (e.g. a
+// permission error opening a file) before the log stream has been consumed.
+type failFastCollector struct{}
+
+func (failFastCollector) CollectLogs(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, containerName string, restartCount int, stream io.ReadCloser) error {
+	return errors.New("boom")
+}
+
+func (failFastCollector) CollectEvent(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, event *corev1.Event) error {
+	return errors.New("boom")
+}
+
+// recordingCollector collects every byte written to it, so tests can assert
+// the full stream made it through.
+type recordingCollector struct {
+	received strings.Builder
+}
+
+func (c *recordingCollector) CollectLogs(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, containerName string, restartCount int, stream io.ReadCloser) error {
+	_, err := io.Copy(&c.received, stream)
+	return err
+}
+
+func (c *recordingCollector) CollectEvent(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, event *corev1.Event) error {
+	return nil
+}
+
+// TestMultiplexCollectorDoesNotStallOnAFailingCollector covers the deadlock
+// this collector used to be prone to: if one fanned-out collector returns
+// before draining its pipe, the shared io.MultiWriter write to that pipe
+// would block forever, stalling delivery to every other collector too.
+func TestMultiplexCollectorDoesNotStallOnAFailingCollector(t *testing.T) {
+	recording := &recordingCollector{}
+	mc := NewMultiplexCollector(failFastCollector{}, recording)
+
+	content := strings.Repeat("log line\n", 10000) // large enough to fill a pipe's buffer
+	stream := io.NopCloser(strings.NewReader(content))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mc.CollectLogs(context.Background(), logrus.New(), &corev1.Pod{}, "app", 0, stream)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error from the failing collector")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CollectLogs deadlocked instead of returning once all collectors finished")
+	}
+
+	if recording.received.String() != content {
+		t.Fatal("expected the non-failing collector to still receive the full stream")
+	}
+}
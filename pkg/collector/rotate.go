@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// RotationOptions caps how large a single per-container log file may grow
+// before it is rotated out, similar to natefinch/lumberjack's semantics, and
+// optionally streams it through gzip as it is written.
+type RotationOptions struct {
+	// MaxFileSize rotates the active file out before a write that would push
+	// the amount of log data written to it past this many bytes. Zero
+	// disables rotation.
+	MaxFileSize int64
+
+	// MaxFiles bounds how many rotated-out files are kept per base log file
+	// (i.e. per container incarnation); the oldest beyond that are deleted
+	// whenever a rotation happens. Zero means rotated files are never pruned.
+	MaxFiles int
+
+	// Compress streams the log through gzip as it is written, naming the
+	// file "<name>.gz" instead of "<name>".
+	Compress bool
+}
+
+// rotatingWriter is an io.WriteCloser that writes log data to baseFilename
+// (or baseFilename+".gz" if opt.Compress), transparently rotating it out to
+// a timestamped backup once opt.MaxFileSize is exceeded.
+type rotatingWriter struct {
+	baseFilename string
+	opt          RotationOptions
+
+	file    *os.File
+	gz      *gzip.Writer
+	written int64
+}
+
+func newRotatingWriter(baseFilename string, opt RotationOptions) (*rotatingWriter, error) {
+	w := &rotatingWriter{
+		baseFilename: baseFilename,
+		opt:          opt,
+	}
+
+	if err := w.openCurrent(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *rotatingWriter) currentFilename() string {
+	if w.opt.Compress {
+		return w.baseFilename + ".gz"
+	}
+
+	return w.baseFilename
+}
+
+func (w *rotatingWriter) openCurrent() error {
+	f, err := os.OpenFile(w.currentFilename(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.written = 0
+
+	if w.opt.Compress {
+		w.gz = gzip.NewWriter(f)
+	}
+
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	if w.opt.MaxFileSize > 0 && w.written > 0 && w.written+int64(len(p)) > w.opt.MaxFileSize {
+		if err := w.rotate(); err != nil {
+			return 0, fmt.Errorf("failed to rotate %s: %w", w.currentFilename(), err)
+		}
+	}
+
+	var (
+		n   int
+		err error
+	)
+
+	if w.gz != nil {
+		n, err = w.gz.Write(p)
+	} else {
+		n, err = w.file.Write(p)
+	}
+
+	w.written += int64(n)
+
+	return n, err
+}
+
+func (w *rotatingWriter) rotate() error {
+	filename := w.currentFilename()
+
+	if err := w.closeCurrent(); err != nil {
+		return err
+	}
+
+	backup := fmt.Sprintf("%s.%s", filename, time.Now().UTC().Format("20060102T150405.000000"))
+	if err := os.Rename(filename, backup); err != nil {
+		return err
+	}
+
+	if err := w.prune(); err != nil {
+		return err
+	}
+
+	return w.openCurrent()
+}
+
+func (w *rotatingWriter) closeCurrent() error {
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			return err
+		}
+		w.gz = nil
+	}
+
+	return w.file.Close()
+}
+
+// prune deletes the oldest rotated-out backups beyond opt.MaxFiles.
+func (w *rotatingWriter) prune() error {
+	if w.opt.MaxFiles <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(w.currentFilename() + ".*")
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(matches) // timestamp suffixes sort chronologically
+
+	if excess := len(matches) - w.opt.MaxFiles; excess > 0 {
+		for _, m := range matches[:excess] {
+			if err := os.Remove(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *rotatingWriter) Close() error {
+	return w.closeCurrent()
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRotatingWriterRotatesAndPrunes covers the core lumberjack-style
+// arithmetic: once MaxFileSize is exceeded, the current file is renamed out
+// to a timestamped backup and a fresh one is opened, and once more than
+// MaxFiles backups exist, the oldest are pruned.
+func TestRotatingWriterRotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	w, err := newRotatingWriter(filename, RotationOptions{MaxFileSize: 10, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("newRotatingWriter returned an error: %v", err)
+	}
+
+	// Each write fills the cap exactly, so every write after the first would
+	// push the running total over it and forces a rotation before it; the
+	// four rotations here should leave only the 2 most recent backups plus
+	// the active file.
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte("0123456789")); err != nil {
+			t.Fatalf("Write returned an error: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	backups, err := filepath.Glob(filename + ".*")
+	if err != nil {
+		t.Fatalf("Glob returned an error: %v", err)
+	}
+
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 pruned-down backups, got %d: %v", len(backups), backups)
+	}
+
+	if _, err := os.Stat(filename); err != nil {
+		t.Fatalf("expected the active file to still exist: %v", err)
+	}
+}
+
+// TestRotatingWriterCompressesActiveFile covers the Compress option: the
+// active file should be written as "<name>.gz" rather than "<name>".
+func TestRotatingWriterCompressesActiveFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	w, err := newRotatingWriter(filename, RotationOptions{Compress: true})
+	if err != nil {
+		t.Fatalf("newRotatingWriter returned an error: %v", err)
+	}
+
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	if _, err := os.Stat(filename + ".gz"); err != nil {
+		t.Fatalf("expected a compressed active file: %v", err)
+	}
+
+	if _, err := os.Stat(filename); err == nil {
+		t.Fatal("did not expect an uncompressed active file to exist")
+	}
+}
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// streamSegmentBoundary is written as its own line whenever a StreamCollector
+// rotates out a segment, so a human tailing the combined, uncompressed
+// stream can see where one segment ends and the next begins.
+const streamSegmentBoundary = "--- loks: log segment boundary (max-file-size reached) ---"
+
+// StreamCollector writes every container's logs and events to a single
+// shared io.Writer (typically os.Stdout), prefixing each line with the
+// originating pod/container so several streams multiplexed onto one pipe
+// can still be told apart. Unlike DiskCollector there is no backing file to
+// rename or prune, so RotationOptions applies with stream-appropriate
+// semantics: MaxFileSize still caps how much is written before a "segment"
+// boundary is cut (a plain marker line, or — with Compress set — a fresh
+// gzip member, since concatenated gzip streams decode transparently), but
+// MaxFiles has no analog, since there is nothing to prune from a live pipe.
+//
+// When Compress is set, callers MUST call Close once the watch is done to
+// write the final gzip trailer (CRC32 + ISIZE) — Flush alone, called after
+// every line, keeps each member readable as it grows but never finalizes
+// it, so a reader that reads to EOF without a Close would see
+// ErrUnexpectedEOF.
+type StreamCollector struct {
+	mu      sync.Mutex
+	w       io.Writer
+	opt     RotationOptions
+	gz      *gzip.Writer
+	written int64
+}
+
+// NewStreamCollector returns a Collector that writes to w, optionally
+// gzip-compressing the combined output if rotation.Compress is set, and
+// cutting a new segment before a line that would push the current one past
+// rotation.MaxFileSize bytes.
+func NewStreamCollector(w io.Writer, rotation RotationOptions) *StreamCollector {
+	c := &StreamCollector{w: w, opt: rotation}
+
+	if rotation.Compress {
+		c.gz = gzip.NewWriter(w)
+	}
+
+	return c
+}
+
+func (c *StreamCollector) CollectLogs(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, containerName string, restartCount int, stream io.ReadCloser) error {
+	prefix := streamPrefix(pod, containerName, restartCount)
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if err := c.writeLine("%s%s\n", prefix, scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (c *StreamCollector) CollectEvent(ctx context.Context, log logrus.FieldLogger, pod *corev1.Pod, event *corev1.Event) error {
+	prefix := fmt.Sprintf("[%s/%s/events] ", pod.Namespace, pod.Name)
+
+	return c.writeLine("%s[%s] %s/%s %s: %s\n", prefix,
+		event.LastTimestamp.Format("2006-01-02T15:04:05Z07:00"), event.Type, event.Reason, event.InvolvedObject.FieldPath, event.Message)
+}
+
+func (c *StreamCollector) writeLine(format string, a ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	line := fmt.Sprintf(format, a...)
+
+	if c.opt.MaxFileSize > 0 && c.written > 0 && c.written+int64(len(line)) > c.opt.MaxFileSize {
+		if err := c.cutSegment(); err != nil {
+			return fmt.Errorf("failed to cut log segment: %w", err)
+		}
+	}
+
+	var (
+		n   int
+		err error
+	)
+
+	if c.gz != nil {
+		n, err = io.WriteString(c.gz, line)
+	} else {
+		n, err = io.WriteString(c.w, line)
+	}
+
+	c.written += int64(n)
+
+	if err != nil {
+		return err
+	}
+
+	if c.gz != nil {
+		return c.gz.Flush()
+	}
+
+	return nil
+}
+
+// cutSegment closes out the current segment once MaxFileSize is exceeded.
+// Uncompressed output gets a plain marker line; compressed output gets a
+// fresh gzip member, since stdout can't be renamed the way a file can.
+func (c *StreamCollector) cutSegment() error {
+	c.written = 0
+
+	if c.gz == nil {
+		_, err := io.WriteString(c.w, streamSegmentBoundary+"\n")
+		return err
+	}
+
+	if err := c.gz.Close(); err != nil {
+		return err
+	}
+
+	c.gz = gzip.NewWriter(c.w)
+
+	return nil
+}
+
+// Close finalizes the gzip stream, if Compress was set; it is a no-op
+// otherwise. It satisfies io.Closer so callers owning a StreamCollector's
+// lifetime (e.g. watcher.Watcher, once its watch loop returns) can close it
+// generically.
+func (c *StreamCollector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gz == nil {
+		return nil
+	}
+
+	return c.gz.Close()
+}
+
+func streamPrefix(pod *corev1.Pod, containerName string, restartCount int) string {
+	if restartCount == 0 {
+		return fmt.Sprintf("[%s/%s/%s] ", pod.Namespace, pod.Name, containerName)
+	}
+
+	return fmt.Sprintf("[%s/%s/%s:restart%d] ", pod.Namespace, pod.Name, containerName, restartCount)
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func collectLine(t *testing.T, c *StreamCollector, pod *corev1.Pod, line string) {
+	t.Helper()
+
+	r, w := io.Pipe()
+	go func() {
+		w.Write([]byte(line + "\n"))
+		w.Close()
+	}()
+
+	if err := c.CollectLogs(context.Background(), logrus.New(), pod, "app", 0, r); err != nil {
+		t.Fatalf("CollectLogs returned an error: %v", err)
+	}
+}
+
+// TestStreamCollectorCutsSegmentOnMaxFileSize covers the uncompressed path:
+// once MaxFileSize is exceeded, a boundary marker line is written instead of
+// the output silently growing forever.
+func TestStreamCollectorCutsSegmentOnMaxFileSize(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := NewStreamCollector(&buf, RotationOptions{MaxFileSize: 10})
+	pod := &corev1.Pod{}
+	pod.Namespace = "default"
+	pod.Name = "pod-a"
+
+	collectLine(t, c, pod, "first line is long enough to exceed the cap")
+	collectLine(t, c, pod, "second")
+
+	if got := buf.String(); !strings.Contains(got, streamSegmentBoundary) {
+		t.Fatalf("expected output to contain a segment boundary marker, got: %s", got)
+	}
+}
+
+// TestStreamCollectorCompressedSegmentsDecodeAsConcatenatedGzip covers the
+// compressed path: cutting a segment closes the current gzip member and
+// opens a fresh one, and Go's gzip reader must still decode the whole thing
+// transparently as a multistream.
+func TestStreamCollectorCompressedSegmentsDecodeAsConcatenatedGzip(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := NewStreamCollector(&buf, RotationOptions{MaxFileSize: 10, Compress: true})
+	pod := &corev1.Pod{}
+	pod.Namespace = "default"
+	pod.Name = "pod-a"
+
+	collectLine(t, c, pod, "first line is long enough to exceed the cap")
+	collectLine(t, c, pod, "second")
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("failed to open gzip reader: %v", err)
+	}
+
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("failed to read decompressed output: %v", err)
+	}
+
+	if got := string(decoded); !strings.Contains(got, "first line") || !strings.Contains(got, "second") {
+		t.Fatalf("expected decoded output to contain both lines, got: %s", got)
+	}
+}
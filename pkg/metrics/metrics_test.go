@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCountingReaderAccumulatesBytesCollected(t *testing.T) {
+	m := NewMetrics()
+
+	r := m.CountingReader("default", "pod-a", "app", io.NopCloser(strings.NewReader("hello world")))
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll returned an error: %v", err)
+	}
+
+	if string(data) != "hello world" {
+		t.Fatalf("expected to read through the full content, got %q", data)
+	}
+
+	got := testutil.ToFloat64(m.BytesCollected.WithLabelValues("default", "pod-a", "app"))
+	if got != float64(len("hello world")) {
+		t.Fatalf("expected BytesCollected to be %d, got %v", len("hello world"), got)
+	}
+}
+
+func TestReadyzReflectsSetReady(t *testing.T) {
+	m := NewMetrics()
+	handler := m.Handler()
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != 503 {
+		t.Fatalf("expected /readyz to be unready by default, got status %d", rec.Code)
+	}
+
+	m.SetReady(true)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("expected /readyz to report ready, got status %d", rec.Code)
+	}
+
+	m.SetReady(false)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != 503 {
+		t.Fatalf("expected /readyz to report unready again, got status %d", rec.Code)
+	}
+}
@@ -0,0 +1,155 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	toolswatch "k8s.io/client-go/tools/watch"
+)
+
+// ParseKind normalizes a short workload kind name (as used on the CLI, e.g.
+// "deploy" or "sts") to the GVR it corresponds to. Unknown kinds return an
+// error so callers can report a helpful usage message.
+func ParseKind(kind string) (schema.GroupVersionResource, error) {
+	switch kind {
+	case "deploy", "deployment", "deployments":
+		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, nil
+	case "sts", "statefulset", "statefulsets":
+		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, nil
+	case "ds", "daemonset", "daemonsets":
+		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, nil
+	case "rs", "replicaset", "replicasets":
+		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, nil
+	case "job", "jobs":
+		return schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, nil
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("unknown workload kind %q", kind)
+	}
+}
+
+// RootResolver turns a workload root (kind/name) into the label selector
+// that matches the pods it owns, so the watcher can be pointed at a
+// Deployment/StatefulSet/DaemonSet/Job/ReplicaSet instead of a raw selector.
+type RootResolver struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewRootResolver creates a RootResolver using the given dynamic client to
+// fetch and watch workload objects.
+func NewRootResolver(dynamicClient dynamic.Interface) *RootResolver {
+	return &RootResolver{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// ResolveSelector fetches the object identified by gvr/namespace/name and
+// derives the pod label selector it implies.
+func (r *RootResolver) ResolveSelector(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (labels.Selector, error) {
+	obj, err := r.dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s/%s: %w", gvr.Resource, namespace, name, err)
+	}
+
+	return r.selectorFromObject(ctx, gvr, obj)
+}
+
+// WatchRoot watches the root object and calls onSelectorChange every time
+// its resolved selector changes (e.g. a rollout that changes pod-template
+// labels), so log collection can follow along. Like Watch, it goes through
+// toolswatch.RetryWatcher so it survives API server restarts and stream
+// disconnects instead of silently giving up on following the rollout.
+func (r *RootResolver) WatchRoot(ctx context.Context, factory WatchInterfaceFactory, resourceVersion string, gvr schema.GroupVersionResource, onSelectorChange func(labels.Selector)) error {
+	retryWatcher, err := toolswatch.NewRetryWatcher(resourceVersion, &watchFuncAdapter{factory: factory})
+	if err != nil {
+		return fmt.Errorf("failed to create retry watcher for %s: %w", gvr.Resource, err)
+	}
+	defer retryWatcher.Stop()
+
+	go func() {
+		<-ctx.Done()
+		retryWatcher.Stop()
+	}()
+
+	last := ""
+
+	for event := range retryWatcher.ResultChan() {
+		obj, ok := event.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		if event.Type != watch.Added && event.Type != watch.Modified {
+			continue
+		}
+
+		selector, err := r.selectorFromObject(ctx, gvr, obj)
+		if err != nil {
+			continue
+		}
+
+		if current := selector.String(); current != last {
+			last = current
+			onSelectorChange(selector)
+		}
+	}
+
+	return nil
+}
+
+func (r *RootResolver) selectorFromObject(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (labels.Selector, error) {
+	var matchLabels map[string]string
+	var err error
+
+	switch gvr.Resource {
+	case "jobs":
+		matchLabels, _, err = unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+	default:
+		matchLabels, _, err = unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matchLabels) == 0 {
+		if owner := ownerReference(obj); owner != nil {
+			// owner.kind comes straight off OwnerReference.Kind, e.g.
+			// "Deployment"/"ReplicaSet"; ParseKind only matches the
+			// lowercase names used on the CLI, so it must be
+			// normalized first or every owner-walk falls through to
+			// "unsupported kind".
+			ownerGVR, parseErr := ParseKind(strings.ToLower(owner.kind))
+			if parseErr != nil {
+				return nil, fmt.Errorf("pod-owning object %s/%s has no selector and its owner %s is not a supported kind", obj.GetNamespace(), obj.GetName(), owner.kind)
+			}
+
+			return r.ResolveSelector(ctx, ownerGVR, obj.GetNamespace(), owner.name)
+		}
+
+		return nil, fmt.Errorf("could not determine pod selector for %s %s/%s", gvr.Resource, obj.GetNamespace(), obj.GetName())
+	}
+
+	return labels.SelectorFromSet(matchLabels), nil
+}
+
+type ownerRef struct {
+	kind string
+	name string
+}
+
+func ownerReference(obj *unstructured.Unstructured) *ownerRef {
+	for _, owner := range obj.GetOwnerReferences() {
+		if sets.NewString("Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job").Has(owner.Kind) {
+			return &ownerRef{kind: owner.Kind, name: owner.Name}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// TestResolveSelectorWalksOwnerReference covers a ReplicaSet that has no
+// spec.selector.matchLabels of its own (as created by a Deployment, which
+// owns it via an OwnerReference rather than duplicating the selector):
+// ResolveSelector must walk up to the owning Deployment and resolve its
+// selector instead of reporting the owner as an unsupported kind.
+func TestResolveSelectorWalksOwnerReference(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "my-deploy",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{
+						"app": "my-deploy",
+					},
+				},
+			},
+		},
+	}
+
+	replicaSet := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "ReplicaSet",
+			"metadata": map[string]interface{}{
+				"name":      "my-deploy-abc123",
+				"namespace": "default",
+				"ownerReferences": []interface{}{
+					map[string]interface{}{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"name":       "my-deploy",
+						"uid":        "11111111-1111-1111-1111-111111111111",
+					},
+				},
+			},
+			"spec": map[string]interface{}{},
+		},
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, deployment, replicaSet)
+	resolver := NewRootResolver(dynamicClient)
+
+	rsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+
+	selector, err := resolver.ResolveSelector(context.Background(), rsGVR, "default", "my-deploy-abc123")
+	if err != nil {
+		t.Fatalf("ResolveSelector returned an error: %v", err)
+	}
+
+	if got, want := selector.String(), "app=my-deploy"; got != want {
+		t.Fatalf("selector = %q, want %q", got, want)
+	}
+}
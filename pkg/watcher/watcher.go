@@ -3,22 +3,34 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"sync"
 
 	"go.xrstf.de/loks/pkg/collector"
+	"go.xrstf.de/loks/pkg/metrics"
 
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	toolswatch "k8s.io/client-go/tools/watch"
 )
 
+// WatchInterfaceFactory builds the underlying watch.Interface used to observe
+// pod changes, starting from the given resourceVersion. It is invoked by the
+// RetryWatcher every time the stream needs to be (re-)established, which lets
+// tests inject a fake implementation instead of talking to a real API server.
+type WatchInterfaceFactory func(resourceVersion string) (watch.Interface, error)
+
 type Watcher struct {
 	clientset      *kubernetes.Clientset
 	log            logrus.FieldLogger
@@ -26,8 +38,21 @@ type Watcher struct {
 	initialPods    []corev1.Pod
 	opt            Options
 	seenContainers sets.String
+	metrics        *metrics.Metrics
+
+	// labelSelectorMu guards opt.LabelSelector, which SetLabelSelector may
+	// update at runtime when the watcher follows a workload root (see
+	// RootResolver.WatchRoot) whose pod-template labels change across a
+	// rollout.
+	labelSelectorMu sync.RWMutex
 }
 
+// DefaultPrimaryContainers is the out-of-the-box value for
+// Options.PrimaryContainers, covering Knative/Istio's sidecar-injection
+// convention. Callers exposing a --primary-container flag should append to
+// this rather than replace it outright, unless the user opts out entirely.
+var DefaultPrimaryContainers = []string{"user-container"}
+
 type Options struct {
 	LabelSelector  labels.Selector
 	Namespaces     []string
@@ -35,6 +60,31 @@ type Options struct {
 	ContainerNames []string
 	RunningOnly    bool
 	OneShot        bool
+
+	// DumpEvents enables collecting corev1.Event objects for every matched
+	// pod, independently of RunningOnly: events are captured even for pods
+	// that never reach Running, since that is often exactly what explains
+	// why they didn't (FailedScheduling, ImagePullBackOff, …).
+	DumpEvents bool
+
+	// DumpEventsRaw additionally has the configured collector persist the
+	// raw event objects (e.g. as YAML), not just a human-readable rendering.
+	// It has no effect unless DumpEvents is set.
+	DumpEventsRaw bool
+
+	// PrimaryContainers lists the container-name conventions (patterns, as
+	// accepted by ContainerNames) that identify a pod's "main" application
+	// container, e.g. "user-container" for Knative/Istio-injected pods. It
+	// only takes effect when ContainerNames is empty: if a pod has a
+	// container matching one of these, only that container is streamed
+	// instead of every sidecar.
+	PrimaryContainers []string
+
+	// MetricsAddr, if set, opts into a Prometheus metrics subsystem and
+	// /healthz, /readyz probes served on this address (e.g. ":9090"),
+	// turning the watcher into something that can be run as a long-lived
+	// sidecar or Deployment with proper observability.
+	MetricsAddr string
 }
 
 func NewWatcher(
@@ -44,7 +94,7 @@ func NewWatcher(
 	initialPods []corev1.Pod,
 	opt Options,
 ) *Watcher {
-	return &Watcher{
+	w := &Watcher{
 		clientset:      clientset,
 		log:            log,
 		collector:      c,
@@ -52,21 +102,67 @@ func NewWatcher(
 		opt:            opt,
 		seenContainers: sets.NewString(),
 	}
+
+	if opt.MetricsAddr != "" {
+		w.metrics = metrics.NewMetrics()
+		go w.serveMetrics()
+	}
+
+	return w
+}
+
+// serveMetrics runs the /metrics, /healthz and /readyz HTTP server for the
+// lifetime of the process. A failure here is logged but never fatal, since
+// metrics are an operational nicety that log collection itself does not
+// depend on.
+func (w *Watcher) serveMetrics() {
+	if err := http.ListenAndServe(w.opt.MetricsAddr, w.metrics.Handler()); err != nil {
+		w.log.WithError(err).Error("Metrics server stopped.")
+	}
 }
 
-func (w *Watcher) Watch(ctx context.Context, wi watch.Interface) {
+// Watch processes the initial pods and then, unless --oneshot was given,
+// keeps watching for further pod changes using the given factory to build
+// (and, transparently after disconnects or API server restarts, rebuild) the
+// underlying watch.Interface. resourceVersion must be the ResourceVersion of
+// the LIST call that produced w.initialPods, so the watch picks up exactly
+// where that list left off.
+func (w *Watcher) Watch(ctx context.Context, factory WatchInterfaceFactory, resourceVersion string) error {
 	wg := sync.WaitGroup{}
 
 	for i := range w.initialPods {
 		if w.podMatchesCriteria(&w.initialPods[i]) {
+			w.observePodMatched(&w.initialPods[i])
 			w.startLogCollectors(ctx, &wg, &w.initialPods[i])
+			w.startEventCollector(ctx, &wg, &w.initialPods[i])
 		}
 	}
 
-	// wi can be nil if we do not want to actually watch, but instead
+	// factory can be nil if we do not want to actually watch, but instead
 	// just process the initial pods (if --oneshot is given)
-	if wi != nil {
-		for event := range wi.ResultChan() {
+	if factory != nil {
+		retryWatcher, err := toolswatch.NewRetryWatcher(resourceVersion, &watchFuncAdapter{factory: w.wrapFactory(factory)})
+		if err != nil {
+			return fmt.Errorf("failed to create retry watcher: %w", err)
+		}
+		defer retryWatcher.Stop()
+
+		// RetryWatcher has no notion of ctx; without this, cancelling ctx
+		// (the normal shutdown path, also used by every collectLogs/
+		// collectEvents goroutine) would leave this loop blocked on
+		// ResultChan() forever.
+		go func() {
+			<-ctx.Done()
+			retryWatcher.Stop()
+		}()
+
+		// readiness is flipped true/false around each (re-)connect by
+		// wrapFactory, which is the only place that actually observes
+		// whether the underlying stream is currently up; this defer just
+		// covers the final "Watch has fully exited" transition.
+		defer w.setReady(false)
+
+		for event := range retryWatcher.ResultChan() {
 			obj, ok := event.Object.(*unstructured.Unstructured)
 			if !ok {
 				continue
@@ -78,28 +174,120 @@ func (w *Watcher) Watch(ctx context.Context, wi watch.Interface) {
 				continue
 			}
 
+			w.observeEvent()
+
 			if w.podMatchesCriteria(pod) {
+				w.observePodMatched(pod)
 				w.startLogCollectors(ctx, &wg, pod)
+				w.startEventCollector(ctx, &wg, pod)
 			}
 		}
 	}
 
 	wg.Wait()
+
+	// Collectors with state to finalize (e.g. a StreamCollector writing a
+	// gzip stream, which needs its trailer written) opt into this by
+	// implementing io.Closer; not every Collector has anything to close.
+	if closer, ok := w.collector.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			w.log.WithError(err).Error("Failed to close collector.")
+		}
+	}
+
+	return nil
+}
+
+// wrapFactory instruments factory so every re-invocation beyond the first —
+// i.e. every time the RetryWatcher has to re-establish the stream after a
+// disconnect or API server restart — bumps the stream-reconnects metric. It
+// is also the only place that actually knows whether the underlying stream
+// is currently connected, so it drives /readyz too: ready flips false for
+// the duration of each (re-)connect attempt, including the very first one,
+// and back to true only once factory has handed back a live watch.Interface.
+func (w *Watcher) wrapFactory(factory WatchInterfaceFactory) WatchInterfaceFactory {
+	if w.metrics == nil {
+		return factory
+	}
+
+	first := true
+
+	return func(resourceVersion string) (watch.Interface, error) {
+		if !first {
+			w.metrics.StreamReconnects.Inc()
+		}
+		first = false
+
+		w.setReady(false)
+
+		wi, err := factory(resourceVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		w.setReady(true)
+
+		return wi, nil
+	}
+}
+
+// observePodMatched counts each pod (by namespace/name) at most once, no
+// matter how many watch events for it match.
+func (w *Watcher) observePodMatched(pod *corev1.Pod) {
+	if w.metrics == nil {
+		return
+	}
+
+	ident := fmt.Sprintf("%s:%s:matched", pod.Namespace, pod.Name)
+	if w.seenContainers.Has(ident) {
+		return
+	}
+	w.seenContainers.Insert(ident)
+
+	w.metrics.PodsMatched.Inc()
+}
+
+func (w *Watcher) observeEvent() {
+	if w.metrics != nil {
+		w.metrics.ObserveEvent()
+	}
+}
+
+func (w *Watcher) setReady(ready bool) {
+	if w.metrics != nil {
+		w.metrics.SetReady(ready)
+	}
+}
+
+// watchFuncAdapter turns a WatchInterfaceFactory into the cache.Watcher
+// interface expected by toolswatch.NewRetryWatcher.
+type watchFuncAdapter struct {
+	factory WatchInterfaceFactory
+}
+
+func (a *watchFuncAdapter) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return a.factory(options.ResourceVersion)
 }
 
 func (w *Watcher) startLogCollectors(ctx context.Context, wg *sync.WaitGroup, pod *corev1.Pod) {
-	w.startLogCollectorsForContainers(ctx, wg, pod, pod.Spec.InitContainers, pod.Status.InitContainerStatuses)
-	w.startLogCollectorsForContainers(ctx, wg, pod, pod.Spec.Containers, pod.Status.ContainerStatuses)
+	// PrimaryContainers only ever narrows down pod.Spec.Containers: init
+	// containers and ephemeral/debug containers must keep being streamed in
+	// full, or the primary-container preference would silently suppress
+	// init-container logs and kubectl debug sessions.
+	w.startLogCollectorsForContainers(ctx, wg, pod, pod.Spec.InitContainers, pod.Status.InitContainerStatuses, false)
+	w.startLogCollectorsForContainers(ctx, wg, pod, pod.Spec.Containers, pod.Status.ContainerStatuses, true)
+	w.startLogCollectorsForContainers(ctx, wg, pod, ephemeralContainersAsContainers(pod.Spec.EphemeralContainers), pod.Status.EphemeralContainerStatuses, false)
 }
 
-func (w *Watcher) startLogCollectorsForContainers(ctx context.Context, wg *sync.WaitGroup, pod *corev1.Pod, containers []corev1.Container, statuses []corev1.ContainerStatus) {
+func (w *Watcher) startLogCollectorsForContainers(ctx context.Context, wg *sync.WaitGroup, pod *corev1.Pod, containers []corev1.Container, statuses []corev1.ContainerStatus, applyPrimaryContainers bool) {
 	podLog := w.getPodLog(pod)
+	allowedNames := w.containerNamesFor(pod, applyPrimaryContainers)
 
 	for _, container := range containers {
 		containerName := container.Name
 		containerLog := podLog.WithField("container", containerName)
 
-		if !w.containerNameMatches(containerName) {
+		if !needleMatchesPatterns(containerName, allowedNames) {
 			containerLog.Debug("Container name does not match.")
 			continue
 		}
@@ -149,6 +337,11 @@ func (w *Watcher) startLogCollectorsForContainers(ctx context.Context, wg *sync.
 func (w *Watcher) collectLogs(ctx context.Context, wg *sync.WaitGroup, log logrus.FieldLogger, pod *corev1.Pod, containerName string, restartCount int) {
 	defer wg.Done()
 
+	if w.metrics != nil {
+		w.metrics.ContainersStreaming.Inc()
+		defer w.metrics.ContainersStreaming.Dec()
+	}
+
 	log.Info("Starting to collect logs…")
 
 	request := w.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
@@ -163,13 +356,65 @@ func (w *Watcher) collectLogs(ctx context.Context, wg *sync.WaitGroup, log logru
 	}
 	defer stream.Close()
 
-	if err := w.collector.CollectLogs(ctx, log, pod, containerName, stream); err != nil {
+	if w.metrics != nil {
+		stream = w.metrics.CountingReader(pod.Namespace, pod.Name, containerName, stream)
+	}
+
+	if err := w.collector.CollectLogs(ctx, log, pod, containerName, restartCount, stream); err != nil {
 		log.WithError(err).Error("Failed to collect logs.")
 	}
 
 	log.Info("Logs have finished.")
 }
 
+// startEventCollector subscribes to corev1.Event objects involving pod and
+// hands each of them to the configured collector, bypassing RunningOnly so
+// that scheduling/pull failures on pods that never start are still captured.
+func (w *Watcher) startEventCollector(ctx context.Context, wg *sync.WaitGroup, pod *corev1.Pod) {
+	if !w.opt.DumpEvents {
+		return
+	}
+
+	ident := fmt.Sprintf("%s:%s:events", pod.Namespace, pod.Name)
+	if w.seenContainers.Has(ident) {
+		return
+	}
+	w.seenContainers.Insert(ident)
+
+	wg.Add(1)
+	go w.collectEvents(ctx, wg, w.getPodLog(pod), pod)
+}
+
+func (w *Watcher) collectEvents(ctx context.Context, wg *sync.WaitGroup, log logrus.FieldLogger, pod *corev1.Pod) {
+	defer wg.Done()
+
+	selector := fields.OneTermEqualSelector("involvedObject.uid", string(pod.UID)).String()
+
+	wi, err := w.clientset.CoreV1().Events(pod.Namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: selector,
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to watch events.")
+		return
+	}
+	defer wi.Stop()
+
+	log.Info("Starting to collect events…")
+
+	for watchEvent := range wi.ResultChan() {
+		event, ok := watchEvent.Object.(*corev1.Event)
+		if !ok {
+			continue
+		}
+
+		if err := w.collector.CollectEvent(ctx, log, pod, event); err != nil {
+			log.WithError(err).Error("Failed to collect event.")
+		}
+	}
+
+	log.Info("Events have finished.")
+}
+
 func (w *Watcher) getPodLog(pod *corev1.Pod) logrus.FieldLogger {
 	return w.log.WithField("pod", pod.Name).WithField("namespace", pod.Namespace)
 }
@@ -201,7 +446,9 @@ func (w *Watcher) resourceNamespaceMatches(log logrus.FieldLogger, pod *corev1.P
 }
 
 func (w *Watcher) resourceLabelsMatches(log logrus.FieldLogger, pod *corev1.Pod) bool {
-	if w.opt.LabelSelector == nil || w.opt.LabelSelector.Matches(labels.Set(pod.Labels)) {
+	selector := w.LabelSelector()
+
+	if selector == nil || selector.Matches(labels.Set(pod.Labels)) {
 		return true
 	}
 
@@ -210,8 +457,61 @@ func (w *Watcher) resourceLabelsMatches(log logrus.FieldLogger, pod *corev1.Pod)
 	return false
 }
 
-func (w *Watcher) containerNameMatches(containerName string) bool {
-	return needleMatchesPatterns(containerName, w.opt.ContainerNames)
+// LabelSelector returns the selector currently used to match pods.
+func (w *Watcher) LabelSelector() labels.Selector {
+	w.labelSelectorMu.RLock()
+	defer w.labelSelectorMu.RUnlock()
+
+	return w.opt.LabelSelector
+}
+
+// SetLabelSelector updates the selector used to match pods. It is safe to
+// call concurrently with Watch, e.g. from a RootResolver.WatchRoot callback
+// that re-resolves a workload root's selector after a rollout.
+func (w *Watcher) SetLabelSelector(selector labels.Selector) {
+	w.labelSelectorMu.Lock()
+	defer w.labelSelectorMu.Unlock()
+
+	w.opt.LabelSelector = selector
+}
+
+// containerNamesFor returns the container name patterns to apply to pod: the
+// user-configured ContainerNames if any were given (these apply regardless
+// of container class), otherwise — only when applyPrimaryContainers is set,
+// i.e. for the pod's regular containers — the names of any "user container"
+// conventions (see Options.PrimaryContainers) found in pod, so that
+// service-mesh sidecars don't drown out the application logs without also
+// silencing init containers or kubectl debug sessions. If neither applies,
+// nil is returned, meaning every container matches.
+func (w *Watcher) containerNamesFor(pod *corev1.Pod, applyPrimaryContainers bool) []string {
+	if len(w.opt.ContainerNames) > 0 {
+		return w.opt.ContainerNames
+	}
+
+	if !applyPrimaryContainers || len(w.opt.PrimaryContainers) == 0 {
+		return nil
+	}
+
+	var present []string
+	for _, container := range pod.Spec.Containers {
+		if needleMatchesPatterns(container.Name, w.opt.PrimaryContainers) {
+			present = append(present, container.Name)
+		}
+	}
+
+	return present
+}
+
+// ephemeralContainersAsContainers adapts EphemeralContainers to the
+// corev1.Container shape startLogCollectorsForContainers expects; only the
+// Name is ever read from it, so nothing else needs to be carried over.
+func ephemeralContainersAsContainers(containers []corev1.EphemeralContainer) []corev1.Container {
+	converted := make([]corev1.Container, 0, len(containers))
+	for _, c := range containers {
+		converted = append(converted, corev1.Container{Name: c.Name})
+	}
+
+	return converted
 }
 
 func nameMatches(name string, pattern string) bool {
@@ -0,0 +1,166 @@
+package watcher
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"go.xrstf.de/loks/pkg/metrics"
+)
+
+// unstructuredPod builds the *unstructured.Unstructured representation Watch
+// expects to find on WatchInterfaceFactory events, with the given
+// resourceVersion so RetryWatcher's version-tracking has something to chew
+// on.
+func unstructuredPod(t *testing.T, name, namespace, resourceVersion string) *unstructured.Unstructured {
+	t.Helper()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			ResourceVersion: resourceVersion,
+		},
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		t.Fatalf("failed to convert pod to unstructured: %v", err)
+	}
+
+	return &unstructured.Unstructured{Object: content}
+}
+
+// TestWatchProcessesInitialPodsWithoutFactory covers the --oneshot path: with
+// a nil factory, Watch must process the initial pods and return on its own,
+// without ever touching a watch.Interface.
+func TestWatchProcessesInitialPodsWithoutFactory(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: "default"},
+	}
+
+	w := NewWatcher(nil, nil, logrus.New(), []corev1.Pod{pod}, Options{
+		Namespaces:    []string{"*"},
+		ResourceNames: []string{"*"},
+		OneShot:       true,
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(context.Background(), nil, "")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Watch returned an error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watch with a nil factory should return once the initial pods are processed")
+	}
+}
+
+// TestWatchReconnectsAndStopsOnContextCancellation exercises the
+// RetryWatcher wiring: once the underlying watch.Interface closes (a
+// disconnect/API server restart), Watch must transparently ask factory for a
+// new one instead of returning, and once ctx is cancelled, Watch must stop
+// blocking on the (otherwise ctx-oblivious) RetryWatcher and return.
+func TestWatchReconnectsAndStopsOnContextCancellation(t *testing.T) {
+	var calls int32
+
+	fakes := make(chan *watch.FakeWatcher, 2)
+
+	factory := func(resourceVersion string) (watch.Interface, error) {
+		atomic.AddInt32(&calls, 1)
+
+		fw := watch.NewFake()
+		fakes <- fw
+
+		return fw, nil
+	}
+
+	w := NewWatcher(nil, nil, logrus.New(), nil, Options{
+		Namespaces:    []string{"*"},
+		ResourceNames: []string{"*"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		// toolswatch.NewRetryWatcher rejects ""/"0" outright ("initial RV
+		// ... is not supported"), so a non-empty resourceVersion is needed
+		// here or the factory is never even invoked.
+		done <- w.Watch(ctx, factory, "1")
+	}()
+
+	first := <-fakes
+	first.Add(unstructuredPod(t, "pod-a", "default", "1"))
+	first.Stop() // simulate the stream dropping
+
+	second := <-fakes
+	second.Add(unstructuredPod(t, "pod-b", "default", "2"))
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Watch returned an error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return after ctx was cancelled")
+	}
+
+	if got := atomic.LoadInt32(&calls); got < 2 {
+		t.Fatalf("expected factory to be called at least twice (initial connect + reconnect), got %d", got)
+	}
+}
+
+// TestWrapFactoryTracksReadiness covers /readyz's actual purpose: it should
+// reflect whether the watch stream is currently connected, not just whether
+// Watch has started or fully exited. wrapFactory is the one place that sees
+// every (re-)connect attempt, so it must flip readiness false for the
+// duration of each attempt and true once it succeeds.
+func TestWrapFactoryTracksReadiness(t *testing.T) {
+	w := NewWatcher(nil, nil, logrus.New(), nil, Options{})
+	w.metrics = metrics.NewMetrics()
+
+	readyDuringAttempt := true
+
+	factory := func(resourceVersion string) (watch.Interface, error) {
+		readyDuringAttempt = w.metrics.IsReady()
+		return watch.NewFake(), nil
+	}
+
+	wrapped := w.wrapFactory(factory)
+
+	if _, err := wrapped(""); err != nil {
+		t.Fatalf("wrapped factory returned an error: %v", err)
+	}
+
+	if readyDuringAttempt {
+		t.Fatal("expected readiness to be false while the connect attempt was in flight")
+	}
+
+	if !w.metrics.IsReady() {
+		t.Fatal("expected readiness to be true after a successful connect")
+	}
+
+	if _, err := wrapped(""); err != nil {
+		t.Fatalf("wrapped factory returned an error: %v", err)
+	}
+
+	if !w.metrics.IsReady() {
+		t.Fatal("expected readiness to be true after a successful reconnect")
+	}
+}